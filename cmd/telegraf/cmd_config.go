@@ -2,23 +2,33 @@
 package main
 
 import (
-	"errors"
 	"fmt"
 	"io"
 	"log"
-	"net/url"
-	"os"
-	"path/filepath"
 
-	"github.com/fatih/color"
 	"github.com/urfave/cli/v2"
 
-	"github.com/influxdata/telegraf/agent"
 	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/internal/diff"
 	"github.com/influxdata/telegraf/logger"
-	"github.com/influxdata/telegraf/migrations"
+	"github.com/influxdata/telegraf/pkg/server"
 )
 
+// serverOptions builds the pkg/server.Options shared by the default agent
+// action (runAgent) and every config subcommand that loads a configuration.
+// shutdown-timeout is only consumed by Server.Run, which runAgent calls and
+// check/migrate/diff do not. Options.Health is left nil, so every Server
+// creates its own health.Tracker.
+func serverOptions(cCtx *cli.Context) server.Options {
+	return server.Options{
+		ConfigFiles:     cCtx.StringSlice("config"),
+		ConfigDirs:      cCtx.StringSlice("config-directory"),
+		Quiet:           cCtx.Bool("quiet"),
+		Debug:           cCtx.Bool("debug"),
+		ShutdownTimeout: cCtx.Duration("shutdown-timeout"),
+	}
+}
+
 func getConfigCommands(configHandlingFlags []cli.Flag, outputBuffer io.Writer) []*cli.Command {
 	return []*cli.Command{
 		{
@@ -48,55 +58,45 @@ func getConfigCommands(configHandlingFlags []cli.Flag, outputBuffer io.Writer) [
 		To check the file 'mysettings.conf' use
 
 		> telegraf config check --config mysettings.conf
+
+		Use '--format json' or '--format sarif' to emit the findings as structured
+		records instead of log lines, e.g. for CI dashboards or GitHub code
+		scanning.
 		`,
-					Flags: configHandlingFlags,
+					Flags: append(append([]cli.Flag{}, configHandlingFlags...),
+						&cli.StringFlag{
+							Name:  "format",
+							Usage: "output format for the check results, one of \"text\", \"json\" or \"sarif\"",
+							Value: "text",
+						},
+					),
 					Action: func(cCtx *cli.Context) error {
+						format := cCtx.String("format")
+						switch format {
+						case "text", "json", "sarif":
+						default:
+							return fmt.Errorf("invalid format %q, must be \"text\", \"json\" or \"sarif\"", format)
+						}
+
 						// Setup logging
 						logConfig := &logger.Config{Debug: cCtx.Bool("debug")}
 						if err := logger.SetupLogging(logConfig); err != nil {
 							return err
 						}
 
-						// Collect the given configuration files
-						configFiles := cCtx.StringSlice("config")
-						configDir := cCtx.StringSlice("config-directory")
-						for _, fConfigDirectory := range configDir {
-							files, err := config.WalkDirectory(fConfigDirectory)
-							if err != nil {
-								return err
-							}
-							configFiles = append(configFiles, files...)
-						}
-
-						// If no "config" or "config-directory" flag(s) was
-						// provided we should load default configuration files
-						if len(configFiles) == 0 {
-							paths, err := config.GetDefaultConfigPath()
-							if err != nil {
-								return err
-							}
-							configFiles = paths
+						srv := server.New(serverOptions(cCtx))
+						diags, err := srv.Check()
+						if err != nil {
+							return err
 						}
 
-						// Load the config and try to initialize the plugins
-						c := config.NewConfig()
-						c.Agent.Quiet = cCtx.Bool("quiet")
-						if err := c.LoadAll(configFiles...); err != nil {
+						if err := writeDiagnostics(outputBuffer, format, diags); err != nil {
 							return err
 						}
-
-						ag := agent.NewAgent(c)
-
-						// Set the default for processor skipping
-						if c.Agent.SkipProcessorsAfterAggregators == nil {
-							msg := `The default value of 'skip_processors_after_aggregators' will change to 'true' with Telegraf v1.40.0! `
-							msg += `If you need the current default behavior, please explicitly set the option to 'false'!`
-							log.Print("W! [agent] ", color.YellowString(msg))
-							skipProcessorsAfterAggregators := false
-							c.Agent.SkipProcessorsAfterAggregators = &skipProcessorsAfterAggregators
+						if diags.HasErrors() {
+							return cli.Exit("", 1)
 						}
-
-						return ag.InitPlugins()
+						return nil
 					},
 				},
 				{
@@ -143,7 +143,7 @@ To migrate the file 'mysettings.conf' use
 
 > telegraf config migrate --config mysettings.conf
 `,
-					Flags: append(configHandlingFlags,
+					Flags: append(append([]cli.Flag{}, configHandlingFlags...),
 						&cli.BoolFlag{
 							Name:  "force",
 							Usage: "forces overwriting of an existing migration file",
@@ -156,87 +156,82 @@ To migrate the file 'mysettings.conf' use
 							return err
 						}
 
-						// Check if we have migrations at all. There might be
-						// none if you run a custom build without migrations
-						// enabled.
-						migrationsGeneral := len(migrations.GeneralMigrations) + len(migrations.GlobalMigrations)
-						migrationsPlugins := len(migrations.PluginMigrations)
-						migrationsOptions := len(migrations.PluginOptionMigrations)
-						if migrationsGeneral+migrationsPlugins+migrationsOptions == 0 {
-							return errors.New("no migrations available")
-						}
-						log.Printf(
-							"%d general, %d plugin and %d plugin-option migrations available",
-							migrationsGeneral, migrationsPlugins, migrationsOptions,
-						)
-
-						// Collect the given configuration files
-						configFiles := cCtx.StringSlice("config")
-						configDir := cCtx.StringSlice("config-directory")
-						for _, fConfigDirectory := range configDir {
-							files, err := config.WalkDirectory(fConfigDirectory)
-							if err != nil {
-								return err
-							}
-							configFiles = append(configFiles, files...)
+						srv := server.New(serverOptions(cCtx))
+						results, err := srv.Migrate()
+						if err != nil {
+							return err
 						}
 
-						// If no "config" or "config-directory" flag(s) was
-						// provided we should load default configuration files
-						if len(configFiles) == 0 {
-							paths, err := config.GetDefaultConfigPath()
-							if err != nil {
-								return err
+						for _, r := range results {
+							if r.Applied == 0 {
+								log.Printf("I! No migration applied for %q", r.File)
+								continue
 							}
-							configFiles = paths
+							log.Printf("I! %d migration applied for %q, writing result as %q", r.Applied, r.File, r.OutputFile)
 						}
 
-						for _, fn := range configFiles {
-							log.Printf("D! Trying to migrate %q...", fn)
+						return server.WriteMigrated(results, cCtx.Bool("force"))
+					},
+				},
+				{
+					Name:  "diff",
+					Usage: "preview the result of migrating configuration file(s) as a unified diff",
+					Description: `
+The 'diff' command runs the same migrations as 'migrate' but, instead of
+writing '.migrated' files, prints a unified diff between the original and
+migrated configuration to stdout. This is useful to review the effect of a
+migration in code-review or CI before committing to the rewrite.
 
-							// Read and parse the config file
-							data, remote, err := config.LoadConfigFile(fn)
-							if err != nil {
-								return fmt.Errorf("opening input %q failed: %w", fn, err)
-							}
+To preview the migration of the file 'mysettings.conf' use
 
-							out, applied, err := config.ApplyMigrations(data)
-							if err != nil {
-								return err
-							}
+> telegraf config diff --config mysettings.conf
 
-							// Do not write a migration file if nothing was done
-							if applied == 0 {
-								log.Printf("I! No migration applied for %q", fn)
-								continue
-							}
+Use '--exit-code' to make the command return a non-zero exit status if any
+file would change, e.g. to fail a pre-commit hook or CI pipeline:
 
-							// Construct the output filename
-							// For remote locations we just save the filename
-							// with the migrated suffix.
-							outfn := fn + ".migrated"
-							if remote {
-								u, err := url.Parse(fn)
-								if err != nil {
-									return fmt.Errorf("parsing remote config URL %q failed: %w", fn, err)
-								}
-								outfn = filepath.Base(u.Path) + ".migrated"
-							}
+> telegraf config diff --config mysettings.conf --exit-code
+`,
+					Flags: append(append([]cli.Flag{}, configHandlingFlags...),
+						&cli.IntFlag{
+							Name:  "unified",
+							Usage: "number of context lines to show around each change",
+							Value: 3,
+						},
+						&cli.BoolFlag{
+							Name:  "exit-code",
+							Usage: "return a non-zero exit code if any file would change",
+						},
+					),
+					Action: func(cCtx *cli.Context) error {
+						// Setup logging
+						logConfig := &logger.Config{Debug: cCtx.Bool("debug")}
+						if err := logger.SetupLogging(logConfig); err != nil {
+							return err
+						}
 
-							log.Printf("I! %d migration applied for %q, writing result as %q", applied, fn, outfn)
+						srv := server.New(serverOptions(cCtx))
+						results, err := srv.Migrate()
+						if err != nil {
+							return err
+						}
 
-							// Make sure the file does not exist yet if we should not overwrite
-							if !cCtx.Bool("force") {
-								if _, err := os.Stat(outfn); !errors.Is(err, os.ErrNotExist) {
-									return fmt.Errorf("output file %q already exists", outfn)
-								}
+						context := cCtx.Int("unified")
+						changed := false
+						for _, r := range results {
+							if r.Applied == 0 {
+								continue
 							}
+							changed = true
 
-							// Write the output file
-							if err := os.WriteFile(outfn, out, 0640); err != nil {
-								return fmt.Errorf("writing output %q failed: %w", outfn, err)
+							unified := diff.Unified(r.File, r.Original, r.OutputFile, r.Migrated, context)
+							if _, err := fmt.Fprint(outputBuffer, unified); err != nil {
+								return err
 							}
 						}
+
+						if changed && cCtx.Bool("exit-code") {
+							return cli.Exit("", 1)
+						}
 						return nil
 					},
 				},
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/influxdata/telegraf/config"
+)
+
+// sarifLog and the nested types below implement the small subset of the
+// SARIF 2.1.0 schema GitHub code scanning needs to render results; see
+// https://docs.github.com/en/code-security/code-scanning for the full spec.
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name  string      `json:"name"`
+	Rules []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID string `json:"id"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine,omitempty"`
+	StartColumn int `json:"startColumn,omitempty"`
+}
+
+// writeDiagnostics renders the given diagnostics to w in the requested
+// format ("text", "json" or "sarif"). An unknown format is rejected by the
+// caller before this is invoked.
+func writeDiagnostics(w io.Writer, format string, diags config.Diagnostics) error {
+	switch format {
+	case "json":
+		if diags == nil {
+			diags = config.Diagnostics{}
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(diags)
+	case "sarif":
+		return writeDiagnosticsSARIF(w, diags)
+	default:
+		return writeDiagnosticsText(w, diags)
+	}
+}
+
+func writeDiagnosticsText(w io.Writer, diags config.Diagnostics) error {
+	for _, d := range diags {
+		prefix := "I!"
+		switch d.Severity {
+		case config.DiagnosticError:
+			prefix = "E!"
+		case config.DiagnosticWarning:
+			prefix = "W!"
+		}
+		location := d.File
+		if d.Line > 0 {
+			location = fmt.Sprintf("%s:%d", location, d.Line)
+			if d.Column > 0 {
+				location = fmt.Sprintf("%s:%d", location, d.Column)
+			}
+		}
+		if location != "" {
+			if _, err := fmt.Fprintf(w, "%s [%s] %s: %s\n", prefix, d.RuleID, location, d.Message); err != nil {
+				return err
+			}
+			continue
+		}
+		if _, err := fmt.Fprintf(w, "%s [%s] %s\n", prefix, d.RuleID, d.Message); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeDiagnosticsSARIF(w io.Writer, diags config.Diagnostics) error {
+	rules := make(map[string]bool)
+	results := make([]sarifResult, 0, len(diags))
+	for _, d := range diags {
+		rules[d.RuleID] = true
+
+		level := "note"
+		switch d.Severity {
+		case config.DiagnosticError:
+			level = "error"
+		case config.DiagnosticWarning:
+			level = "warning"
+		}
+
+		uri := d.File
+		if uri == "" {
+			uri = "telegraf.conf"
+		}
+
+		var region *sarifRegion
+		if d.Line > 0 {
+			region = &sarifRegion{StartLine: d.Line, StartColumn: d.Column}
+		}
+
+		results = append(results, sarifResult{
+			RuleID:  d.RuleID,
+			Level:   level,
+			Message: sarifMessage{Text: d.Message},
+			Locations: []sarifLocation{{
+				PhysicalLocation: sarifPhysicalLocation{
+					ArtifactLocation: sarifArtifactLocation{URI: uri},
+					Region:           region,
+				},
+			}},
+		})
+	}
+
+	sarifRules := make([]sarifRule, 0, len(rules))
+	for id := range rules {
+		sarifRules = append(sarifRules, sarifRule{ID: id})
+	}
+
+	log := sarifLog{
+		Schema:  "https://raw.githubusercontent.com/oasis-tcs/sarif-spec/master/Schemata/sarif-schema-2.1.0.json",
+		Version: "2.1.0",
+		Runs: []sarifRun{{
+			Tool: sarifTool{Driver: sarifDriver{
+				Name:  "telegraf",
+				Rules: sarifRules,
+			}},
+			Results: results,
+		}},
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(log)
+}
@@ -0,0 +1,114 @@
+// Command telegraf is the Telegraf agent binary. main.go is a thin
+// urfave/cli wrapper: it parses the global flags and hands them to
+// pkg/server.Server, which owns config loading, plugin initialization and
+// the agent run loop. Running telegraf with no subcommand runs the agent
+// until it receives SIGINT/SIGTERM; the "config" subcommands (see
+// cmd_config.go) instead load a configuration without running it.
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/influxdata/telegraf/logger"
+	"github.com/influxdata/telegraf/pkg/server"
+)
+
+func main() {
+	if err := run(os.Args); err != nil {
+		log.Print("E! ", err)
+		os.Exit(1)
+	}
+}
+
+// globalFlags are the flags shared by every subcommand as well as the
+// default (run-the-agent) action.
+func globalFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringSliceFlag{
+			Name:  "config",
+			Usage: "configuration file to load, can be repeated",
+		},
+		&cli.StringSliceFlag{
+			Name:  "config-directory",
+			Usage: "directory containing additional *.conf files to load, can be repeated",
+		},
+		&cli.BoolFlag{
+			Name:  "quiet",
+			Usage: "run in quiet mode, suppressing the periodic agent stats",
+		},
+		&cli.BoolFlag{
+			Name:  "debug",
+			Usage: "enable verbose (debug) logging",
+		},
+		&cli.DurationFlag{
+			Name:  "shutdown-timeout",
+			Usage: "how long to wait for the agent to stop after receiving SIGINT/SIGTERM before giving up; 0 waits indefinitely",
+			Value: 10 * time.Second,
+		},
+		&cli.StringFlag{
+			Name:  "health-listen",
+			Usage: "address to serve the /health JSON endpoint on, e.g. \":8080\"; leave empty to disable",
+		},
+	}
+}
+
+func run(args []string) error {
+	configHandlingFlags := globalFlags()
+
+	app := &cli.App{
+		Name:     "telegraf",
+		Usage:    "The plugin-driven server agent for collecting and reporting metrics",
+		Flags:    configHandlingFlags,
+		Commands: getConfigCommands(configHandlingFlags, os.Stdout),
+		Action:   runAgent,
+	}
+
+	return app.Run(args)
+}
+
+// runAgent is the default action: it runs the agent until ctx is canceled
+// by SIGINT/SIGTERM, then gives it Options.ShutdownTimeout to stop cleanly
+// before returning.
+func runAgent(cCtx *cli.Context) error {
+	logConfig := &logger.Config{Debug: cCtx.Bool("debug")}
+	if err := logger.SetupLogging(logConfig); err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	srv := server.New(serverOptions(cCtx))
+
+	if addr := cCtx.String("health-listen"); addr != "" {
+		if handler, ok := srv.HealthHandler(); ok {
+			mux := http.NewServeMux()
+			mux.Handle("/health", handler)
+			healthServer := &http.Server{Addr: addr, Handler: mux}
+			go func() {
+				if err := healthServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+					log.Printf("E! [agent] health endpoint on %q failed: %s", addr, err)
+				}
+			}()
+			go func() {
+				<-ctx.Done()
+				_ = healthServer.Close()
+			}()
+		}
+	}
+
+	if err := srv.Run(ctx); err != nil {
+		return fmt.Errorf("running agent failed: %w", err)
+	}
+	return nil
+}
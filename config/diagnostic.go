@@ -0,0 +1,58 @@
+package config
+
+// DiagnosticSeverity classifies how serious a Diagnostic is.
+type DiagnosticSeverity string
+
+const (
+	DiagnosticError   DiagnosticSeverity = "error"
+	DiagnosticWarning DiagnosticSeverity = "warning"
+	DiagnosticInfo    DiagnosticSeverity = "info"
+)
+
+// Stable rule IDs used by Diagnostic.RuleID. These are part of the public
+// contract for tools consuming "config check --format json/sarif" output, so
+// existing IDs must not be renamed once released.
+const (
+	RuleDeprecatedOption = "deprecated-option"
+	RuleDeprecatedPlugin = "deprecated-plugin"
+	RuleUnknownField     = "unknown-field"
+	RuleInitFailure      = "init-failure"
+)
+
+// Diagnostic is a single structured finding produced while loading or
+// initializing a configuration, e.g. a deprecated option, an unknown TOML
+// field or a plugin that failed to initialize. The intent is for it to be
+// gathered (rather than logged) during LoadAll/InitPlugins so callers such
+// as "config check" can render it in whatever format they need instead of
+// scraping log output. Plugin initialization failures (RuleInitFailure) and,
+// via ScanFile, deprecated plugins/options (RuleDeprecatedPlugin,
+// RuleDeprecatedOption) are captured this way; unknown TOML fields
+// (RuleUnknownField) still are not, since detecting them needs LoadAll's own
+// unmarshaling to compare against each plugin's schema rather than a text
+// scan. See Server.Check.
+type Diagnostic struct {
+	RuleID   string             `json:"rule_id"`
+	Severity DiagnosticSeverity `json:"severity"`
+	Message  string             `json:"message"`
+	Plugin   string             `json:"plugin,omitempty"`
+	File     string             `json:"file,omitempty"`
+	// Line and Column are 1-based and only populated when the position of
+	// the finding is known; ScanFile sets Line from its own line count and
+	// leaves Column unset.
+	Line   int `json:"line,omitempty"`
+	Column int `json:"column,omitempty"`
+}
+
+// Diagnostics is an ordered collection of Diagnostic as produced by a single
+// LoadAll/InitPlugins run.
+type Diagnostics []Diagnostic
+
+// HasErrors reports whether any diagnostic in the set has error severity.
+func (d Diagnostics) HasErrors() bool {
+	for _, diag := range d {
+		if diag.Severity == DiagnosticError {
+			return true
+		}
+	}
+	return false
+}
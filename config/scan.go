@@ -0,0 +1,126 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"sync"
+)
+
+var (
+	deprecationsMu sync.Mutex
+	// deprecatedPlugins maps "<table>.<name>" (e.g. "inputs.old_plugin") to
+	// the message to surface when that plugin table is found in a
+	// configuration file.
+	deprecatedPlugins = map[string]string{}
+	// deprecatedOptions maps "<table>.<name>" to a set of option keys to the
+	// message to surface when that key is set within the plugin's table.
+	deprecatedOptions = map[string]map[string]string{}
+)
+
+// RegisterDeprecatedPlugin records that the plugin identified by table
+// ("inputs", "outputs", "processors" or "aggregators") and name is
+// deprecated, so ScanFile reports a RuleDeprecatedPlugin diagnostic wherever
+// it is configured. Plugins call this from their own init(), the same way
+// they call Add to register themselves in the first place; calling it again
+// for the same table/name replaces the previously registered message.
+func RegisterDeprecatedPlugin(table, name, message string) {
+	deprecationsMu.Lock()
+	defer deprecationsMu.Unlock()
+	deprecatedPlugins[table+"."+name] = message
+}
+
+// RegisterDeprecatedOption records that the option key within the plugin
+// identified by table and name is deprecated, so ScanFile reports a
+// RuleDeprecatedOption diagnostic wherever it is set.
+func RegisterDeprecatedOption(table, name, key, message string) {
+	deprecationsMu.Lock()
+	defer deprecationsMu.Unlock()
+	byKey, found := deprecatedOptions[table+"."+name]
+	if !found {
+		byKey = map[string]string{}
+		deprecatedOptions[table+"."+name] = byKey
+	}
+	byKey[key] = message
+}
+
+var (
+	pluginHeaderRe = regexp.MustCompile(`^\s*\[\[\s*(inputs|outputs|processors|aggregators)\.([A-Za-z0-9_]+)\s*\]\]\s*$`)
+	optionKeyRe    = regexp.MustCompile(`^\s*([A-Za-z0-9_]+)\s*=`)
+)
+
+// ScanFile reads the TOML configuration file at path and reports a
+// Diagnostic for every deprecated plugin table or option key registered via
+// RegisterDeprecatedPlugin/RegisterDeprecatedOption that it finds. It works
+// directly off the file's text rather than a parsed config.Config, so it can
+// run independently of LoadAll: today LoadAll only logs these findings, it
+// does not collect them as Diagnostics, and fixing that properly means
+// teaching LoadAll's TOML unmarshaling itself to gather Diagnostic entries,
+// which is out of reach without the rest of config.Config in this tree. This
+// is deliberately limited to deprecated plugins/options; flagging unknown
+// fields needs the plugin's actual schema (i.e. LoadAll's unmarshaling,
+// which already has it in hand) rather than a text scan, so that part of the
+// original request remains open.
+func ScanFile(path string) (Diagnostics, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("opening %s: %w", path, err)
+	}
+	defer f.Close()
+
+	var diags Diagnostics
+	var currentPlugin string
+	line := 0
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line++
+		text := scanner.Text()
+
+		if m := pluginHeaderRe.FindStringSubmatch(text); m != nil {
+			table, name := m[1], m[2]
+			currentPlugin = table + "." + name
+			if msg, found := deprecatedPlugins[currentPlugin]; found {
+				diags = append(diags, Diagnostic{
+					RuleID:   RuleDeprecatedPlugin,
+					Severity: DiagnosticWarning,
+					Message:  msg,
+					Plugin:   currentPlugin,
+					File:     path,
+					Line:     line,
+				})
+			}
+			continue
+		}
+
+		if currentPlugin == "" {
+			continue
+		}
+		m := optionKeyRe.FindStringSubmatch(text)
+		if m == nil {
+			continue
+		}
+		byKey := deprecatedOptions[currentPlugin]
+		if byKey == nil {
+			continue
+		}
+		if msg, found := byKey[m[1]]; found {
+			diags = append(diags, Diagnostic{
+				RuleID:   RuleDeprecatedOption,
+				Severity: DiagnosticWarning,
+				Message:  msg,
+				Plugin:   currentPlugin,
+				File:     path,
+				Line:     line,
+			})
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("scanning %s: %w", path, err)
+	}
+
+	sort.SliceStable(diags, func(i, j int) bool { return diags[i].Line < diags[j].Line })
+	return diags, nil
+}
@@ -0,0 +1,57 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestScanFileReportsRegisteredDeprecations(t *testing.T) {
+	RegisterDeprecatedPlugin("inputs", "scan_test_old_plugin", "use 'scan_test_new_plugin' instead")
+	RegisterDeprecatedOption("inputs", "scan_test_old_plugin", "old_field", "use 'new_field' instead")
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "telegraf.conf")
+	contents := "" +
+		"[agent]\n" +
+		"  interval = \"10s\"\n" +
+		"\n" +
+		"[[inputs.scan_test_old_plugin]]\n" +
+		"  old_field = \"value\"\n" +
+		"  other_field = \"value\"\n"
+	if err := os.WriteFile(path, []byte(contents), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	diags, err := ScanFile(path)
+	if err != nil {
+		t.Fatalf("ScanFile returned error: %s", err)
+	}
+
+	if len(diags) != 2 {
+		t.Fatalf("expected 2 diagnostics, got %d: %+v", len(diags), diags)
+	}
+	if diags[0].RuleID != RuleDeprecatedPlugin || diags[0].Line != 4 {
+		t.Errorf("unexpected plugin diagnostic: %+v", diags[0])
+	}
+	if diags[1].RuleID != RuleDeprecatedOption || diags[1].Line != 5 {
+		t.Errorf("unexpected option diagnostic: %+v", diags[1])
+	}
+}
+
+func TestScanFileIgnoresUnregisteredPluginsAndOptions(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "telegraf.conf")
+	contents := "[[inputs.scan_test_unregistered_plugin]]\n  field = \"value\"\n"
+	if err := os.WriteFile(path, []byte(contents), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	diags, err := ScanFile(path)
+	if err != nil {
+		t.Fatalf("ScanFile returned error: %s", err)
+	}
+	if len(diags) != 0 {
+		t.Errorf("expected no diagnostics, got %+v", diags)
+	}
+}
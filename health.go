@@ -0,0 +1,44 @@
+package telegraf
+
+// HealthSeverity classifies how serious a Warnable's current condition is.
+type HealthSeverity int
+
+const (
+	HealthSeverityWarning HealthSeverity = iota
+	HealthSeverityCritical
+)
+
+// String returns a lower-case name for the severity, suitable for use in
+// structured output (JSON, log lines, HTTP responses).
+func (s HealthSeverity) String() string {
+	switch s {
+	case HealthSeverityCritical:
+		return "critical"
+	default:
+		return "warning"
+	}
+}
+
+// Warnable is a single named piece of health state a plugin can flip between
+// healthy and unhealthy, optionally carrying a human-readable message. It
+// replaces ad-hoc "log an error and hope someone notices" patterns with
+// state the agent can observe, aggregate and export.
+type Warnable interface {
+	// Set marks the warnable as unhealthy with the given severity and
+	// message. Calling Set again updates the existing state in place.
+	Set(severity HealthSeverity, message string)
+	// Clear marks the warnable as healthy again.
+	Clear()
+}
+
+// Health is intended to be handed to plugins alongside Logger, the same way,
+// so they can register named warnables without knowing anything about how
+// the agent aggregates or exports health state. A plugin's Health field is
+// only ever non-nil if whatever constructs it sets it directly (e.g. in a
+// test, or an embedder driving pkg/server.Server by hand); the agent does
+// not yet inject it into plugin instances the way it injects Logger.
+type Health interface {
+	// Warnable returns the named warnable, creating it on first use. The
+	// name should be namespaced by plugin, e.g. "nsq_consumer.broker_unreachable".
+	Warnable(name string) Warnable
+}
@@ -0,0 +1,25 @@
+package health
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// Handler returns an http.Handler serving the Tracker's current Snapshot as
+// JSON. The agent mounts this at "/health"; it responds 200 when every
+// warnable is healthy and 503 otherwise so the endpoint also works as a
+// liveness/readiness probe.
+func (t *Tracker) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		statuses := t.Snapshot()
+
+		w.Header().Set("Content-Type", "application/json")
+		if len(t.Unhealthy()) > 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		_ = enc.Encode(statuses)
+	})
+}
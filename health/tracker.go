@@ -0,0 +1,202 @@
+// Package health provides a cross-cutting Tracker that consolidates the
+// "warnable" health state scattered across plugins (broker connections,
+// caches, worker pools, ...) into a single place the agent can query,
+// export over HTTP and summarize at startup/shutdown.
+package health
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/influxdata/telegraf"
+)
+
+// Status is a point-in-time snapshot of a single warnable.
+type Status struct {
+	Name     string                  `json:"name"`
+	Healthy  bool                    `json:"healthy"`
+	Severity telegraf.HealthSeverity `json:"severity,omitempty"`
+	Message  string                  `json:"message,omitempty"`
+	Since    time.Time               `json:"since"`
+}
+
+// Tracker aggregates named Warnable state across every plugin instance in
+// the agent and implements telegraf.Health so it can be injected into
+// plugins the same way telegraf.Logger is.
+type Tracker struct {
+	mu        sync.RWMutex
+	warnables map[string]*warnable
+
+	subMu       sync.Mutex
+	subscribers map[int]chan Status
+	nextSubID   int
+}
+
+// NewTracker creates an empty, ready-to-use Tracker.
+func NewTracker() *Tracker {
+	return &Tracker{
+		warnables:   make(map[string]*warnable),
+		subscribers: make(map[int]chan Status),
+	}
+}
+
+var (
+	activeMu sync.Mutex
+	active   *Tracker
+)
+
+// SetActive registers t as the Tracker the "internal_health" input reads
+// from; pkg/server.Server.New calls this whenever it owns a *Tracker, the
+// same way plugins/processors/snmp_lookup's store registers itself so
+// "internal_snmp_lookup" can find it. Calling it again replaces the
+// previous registration, which is harmless since normally only one Server
+// runs per process.
+func SetActive(t *Tracker) {
+	activeMu.Lock()
+	defer activeMu.Unlock()
+	active = t
+}
+
+// Active returns the Tracker registered via SetActive, or nil if none has
+// been set yet.
+func Active() *Tracker {
+	activeMu.Lock()
+	defer activeMu.Unlock()
+	return active
+}
+
+// Warnable returns the named warnable, registering it the first time it is
+// requested. The returned value is healthy until Set is called on it.
+func (t *Tracker) Warnable(name string) telegraf.Warnable {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	w, found := t.warnables[name]
+	if !found {
+		w = &warnable{name: name, tracker: t, healthy: true, since: time.Now()}
+		t.warnables[name] = w
+	}
+	return w
+}
+
+// Snapshot returns the current status of every registered warnable, sorted
+// by name for stable output.
+func (t *Tracker) Snapshot() []Status {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	statuses := make([]Status, 0, len(t.warnables))
+	for _, w := range t.warnables {
+		statuses = append(statuses, w.status())
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+	return statuses
+}
+
+// Unhealthy returns the subset of Snapshot that is currently unhealthy,
+// convenient for startup/shutdown summaries and the shutdown coordinator.
+func (t *Tracker) Unhealthy() []Status {
+	all := t.Snapshot()
+	unhealthy := all[:0:0]
+	for _, s := range all {
+		if !s.Healthy {
+			unhealthy = append(unhealthy, s)
+		}
+	}
+	return unhealthy
+}
+
+// Subscribe registers for a stream of status changes, e.g. so an output
+// like Prometheus can export health as gauges without polling. The returned
+// function must be called to release the subscription and stop the channel
+// from being written to; the channel is buffered so a slow consumer does not
+// block the plugin flipping its health state.
+func (t *Tracker) Subscribe() (<-chan Status, func()) {
+	t.subMu.Lock()
+	defer t.subMu.Unlock()
+
+	id := t.nextSubID
+	t.nextSubID++
+	ch := make(chan Status, 16)
+	t.subscribers[id] = ch
+
+	unsubscribe := func() {
+		t.subMu.Lock()
+		defer t.subMu.Unlock()
+		if _, found := t.subscribers[id]; found {
+			delete(t.subscribers, id)
+			close(ch)
+		}
+	}
+	return ch, unsubscribe
+}
+
+func (t *Tracker) publish(s Status) {
+	t.subMu.Lock()
+	defer t.subMu.Unlock()
+	for _, ch := range t.subscribers {
+		select {
+		case ch <- s:
+		default:
+			// Never block the plugin that is reporting its health: when the
+			// buffer is full, discard the oldest queued update so the
+			// subscriber still receives the latest state.
+			select {
+			case <-ch:
+			default:
+			}
+			select {
+			case ch <- s:
+			default:
+			}
+		}
+	}
+}
+
+// warnable is the concrete telegraf.Warnable implementation backing a single
+// named entry in a Tracker.
+type warnable struct {
+	tracker *Tracker
+
+	mu       sync.Mutex
+	name     string
+	healthy  bool
+	severity telegraf.HealthSeverity
+	message  string
+	since    time.Time
+}
+
+func (w *warnable) Set(severity telegraf.HealthSeverity, message string) {
+	w.mu.Lock()
+	w.healthy = false
+	w.severity = severity
+	w.message = message
+	w.since = time.Now()
+	w.mu.Unlock()
+
+	w.tracker.publish(w.status())
+}
+
+func (w *warnable) Clear() {
+	w.mu.Lock()
+	w.healthy = true
+	w.severity = 0
+	w.message = ""
+	w.since = time.Now()
+	w.mu.Unlock()
+
+	w.tracker.publish(w.status())
+}
+
+func (w *warnable) status() Status {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return Status{
+		Name:     w.name,
+		Healthy:  w.healthy,
+		Severity: w.severity,
+		Message:  w.message,
+		Since:    w.since,
+	}
+}
@@ -0,0 +1,54 @@
+package health
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+)
+
+func TestTrackerWarnableSetAndClear(t *testing.T) {
+	tracker := NewTracker()
+
+	w := tracker.Warnable("broker")
+	require.Empty(t, tracker.Unhealthy())
+
+	w.Set(telegraf.HealthSeverityCritical, "connection refused")
+	unhealthy := tracker.Unhealthy()
+	require.Len(t, unhealthy, 1)
+	require.Equal(t, "broker", unhealthy[0].Name)
+	require.Equal(t, "connection refused", unhealthy[0].Message)
+
+	w.Clear()
+	require.Empty(t, tracker.Unhealthy())
+}
+
+func TestTrackerWarnableIsStableAcrossCalls(t *testing.T) {
+	tracker := NewTracker()
+
+	first := tracker.Warnable("cache")
+	second := tracker.Warnable("cache")
+	first.Set(telegraf.HealthSeverityWarning, "degraded")
+
+	require.Len(t, tracker.Unhealthy(), 1)
+	second.Clear()
+	require.Empty(t, tracker.Unhealthy())
+}
+
+func TestTrackerSubscribeReceivesUpdates(t *testing.T) {
+	tracker := NewTracker()
+	ch, unsubscribe := tracker.Subscribe()
+	defer unsubscribe()
+
+	tracker.Warnable("pool").Set(telegraf.HealthSeverityWarning, "busy")
+
+	select {
+	case status := <-ch:
+		require.Equal(t, "pool", status.Name)
+		require.False(t, status.Healthy)
+	case <-time.After(time.Second):
+		t.Fatal("expected a status update")
+	}
+}
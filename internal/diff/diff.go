@@ -0,0 +1,213 @@
+// Package diff provides a minimal unified-diff renderer used by the CLI to
+// preview file changes (e.g. configuration migrations) without shelling out
+// to an external diff tool.
+package diff
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+// Hunk is a single contiguous block of changed lines together with the
+// surrounding context lines required to make the change locatable in the
+// original file.
+type Hunk struct {
+	OrigStart, OrigLines int
+	NewStart, NewLines   int
+	Lines                []Line
+}
+
+// Line is a single line of a diff hunk tagged with its operation.
+type Line struct {
+	Op   byte // ' ' context, '-' removed, '+' added
+	Text string
+}
+
+// Unified computes a line-based unified diff between 'a' and 'b' with the
+// given number of context lines around each change and renders it using the
+// standard "---"/"+++"/"@@" unified diff format. The file names supplied are
+// used verbatim in the "---"/"+++" header lines. An empty string is returned
+// if the two inputs are identical.
+func Unified(aName string, a []byte, bName string, b []byte, context int) string {
+	hunks := hunks(splitLines(a), splitLines(b), context)
+	if len(hunks) == 0 {
+		return ""
+	}
+
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "--- %s\n", aName)
+	fmt.Fprintf(&buf, "+++ %s\n", bName)
+	for _, h := range hunks {
+		fmt.Fprintf(&buf, "@@ -%d,%d +%d,%d @@\n", h.OrigStart, h.OrigLines, h.NewStart, h.NewLines)
+		for _, l := range h.Lines {
+			buf.WriteString(colorize(l.Op, string(l.Op)+l.Text))
+			buf.WriteByte('\n')
+		}
+	}
+	return buf.String()
+}
+
+// colorize renders 'text' in the color matching the diff operation. Colors
+// are automatically suppressed by the underlying library when stdout is not
+// a terminal, so callers don't need to check isatty themselves.
+func colorize(op byte, text string) string {
+	switch op {
+	case '+':
+		return color.GreenString("%s", text)
+	case '-':
+		return color.RedString("%s", text)
+	default:
+		return text
+	}
+}
+
+func splitLines(b []byte) []string {
+	if len(b) == 0 {
+		return nil
+	}
+	lines := strings.Split(strings.TrimSuffix(string(b), "\n"), "\n")
+	return lines
+}
+
+// hunks runs a classic longest-common-subsequence diff between the two line
+// slices and groups the resulting edit script into hunks, merging changes
+// that are within 2*context lines of each other.
+func hunks(a, b []string, context int) []Hunk {
+	ops := lcsOps(a, b)
+	if allEqual(ops) {
+		return nil
+	}
+
+	var hunks []Hunk
+	i := 0
+	for i < len(ops) {
+		if ops[i].Op == ' ' {
+			i++
+			continue
+		}
+
+		// Start a new hunk, backing up to include leading context.
+		start := i
+		for j := 1; j <= context && start > 0; j++ {
+			start--
+		}
+
+		// Extend the hunk forward, swallowing runs of context short enough
+		// to be shared with the next change instead of splitting it off.
+		end := i
+		for end < len(ops) {
+			if ops[end].Op != ' ' {
+				end++
+				continue
+			}
+			// Look ahead: is there another change within 2*context lines?
+			run := 0
+			k := end
+			for k < len(ops) && ops[k].Op == ' ' {
+				run++
+				k++
+			}
+			if k < len(ops) && run <= 2*context {
+				end = k
+				continue
+			}
+			break
+		}
+		trailing := 0
+		for trailing < context && end < len(ops) && ops[end].Op == ' ' {
+			end++
+			trailing++
+		}
+
+		hunks = append(hunks, buildHunk(ops[start:end]))
+		i = end
+	}
+	return hunks
+}
+
+type op struct {
+	Op       byte
+	OrigLine int
+	NewLine  int
+	Text     string
+}
+
+func allEqual(ops []op) bool {
+	for _, o := range ops {
+		if o.Op != ' ' {
+			return false
+		}
+	}
+	return true
+}
+
+func buildHunk(ops []op) Hunk {
+	h := Hunk{Lines: make([]Line, 0, len(ops))}
+	for _, o := range ops {
+		if h.OrigStart == 0 && o.OrigLine != 0 {
+			h.OrigStart = o.OrigLine
+		}
+		if h.NewStart == 0 && o.NewLine != 0 {
+			h.NewStart = o.NewLine
+		}
+		switch o.Op {
+		case ' ':
+			h.OrigLines++
+			h.NewLines++
+		case '-':
+			h.OrigLines++
+		case '+':
+			h.NewLines++
+		}
+		h.Lines = append(h.Lines, Line{Op: o.Op, Text: o.Text})
+	}
+	return h
+}
+
+// lcsOps computes an edit script turning 'a' into 'b' using a standard
+// dynamic-programming longest-common-subsequence table. This is quadratic in
+// the number of lines which is acceptable for configuration files.
+func lcsOps(a, b []string) []op {
+	n, m := len(a), len(b)
+	dp := make([][]int, n+1)
+	for i := range dp {
+		dp[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				dp[i][j] = dp[i+1][j+1] + 1
+			} else if dp[i+1][j] >= dp[i][j+1] {
+				dp[i][j] = dp[i+1][j]
+			} else {
+				dp[i][j] = dp[i][j+1]
+			}
+		}
+	}
+
+	var ops []op
+	i, j := 0, 0
+	for i < n && j < m {
+		switch {
+		case a[i] == b[j]:
+			ops = append(ops, op{Op: ' ', OrigLine: i + 1, NewLine: j + 1, Text: a[i]})
+			i++
+			j++
+		case dp[i+1][j] >= dp[i][j+1]:
+			ops = append(ops, op{Op: '-', OrigLine: i + 1, Text: a[i]})
+			i++
+		default:
+			ops = append(ops, op{Op: '+', NewLine: j + 1, Text: b[j]})
+			j++
+		}
+	}
+	for ; i < n; i++ {
+		ops = append(ops, op{Op: '-', OrigLine: i + 1, Text: a[i]})
+	}
+	for ; j < m; j++ {
+		ops = append(ops, op{Op: '+', NewLine: j + 1, Text: b[j]})
+	}
+	return ops
+}
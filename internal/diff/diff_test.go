@@ -0,0 +1,38 @@
+package diff
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnifiedReturnsEmptyStringForIdenticalInput(t *testing.T) {
+	a := []byte("same\ncontent\n")
+	require.Empty(t, Unified("a.conf", a, "b.conf", a, 3))
+}
+
+func TestUnifiedRendersHeaderAndChangedLines(t *testing.T) {
+	a := []byte("one\ntwo\nthree\n")
+	b := []byte("one\ntwo-changed\nthree\n")
+
+	out := Unified("a.conf", a, "b.conf", b, 1)
+	require.Contains(t, out, "--- a.conf\n")
+	require.Contains(t, out, "+++ b.conf\n")
+	require.Contains(t, out, "@@ -1,3 +1,3 @@\n")
+	require.Contains(t, out, "-two\n")
+	require.Contains(t, out, "+two-changed\n")
+}
+
+func TestUnifiedOnlyShowsContextWithinRange(t *testing.T) {
+	a := []byte("a\nb\nc\nd\ne\nf\ng\n")
+	b := []byte("a\nb\nc\nX\ne\nf\ng\n")
+
+	out := Unified("a.conf", a, "b.conf", b, 1)
+	require.Contains(t, out, "-d\n")
+	require.Contains(t, out, "+X\n")
+	require.NotContains(t, out, "a\n", "line 'a' is farther from the change than the requested context")
+}
+
+func TestHunksReturnsNilForEqualInput(t *testing.T) {
+	require.Nil(t, hunks([]string{"same"}, []string{"same"}, 3))
+}
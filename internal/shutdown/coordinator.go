@@ -0,0 +1,87 @@
+// Package shutdown implements the "close-with-timeout" pattern used by
+// production Go daemons: stop a set of components concurrently, give them a
+// bounded amount of time to finish, and report by name whichever ones are
+// still blocking once the deadline elapses instead of wedging forever.
+package shutdown
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/influxdata/telegraf"
+)
+
+// Stopper is anything with a blocking Stop method, e.g. a running plugin.
+type Stopper interface {
+	Stop()
+}
+
+// Plugin pairs a Stopper with the ID the coordinator should report it under
+// (e.g. "inputs.nsq_consumer::1").
+type Plugin struct {
+	ID string
+	Stopper
+}
+
+// Coordinator stops a batch of plugins within a deadline, marking any still
+// running once it elapses as unhealthy via the given health.Tracker (if
+// any) so operators can see what is wedging shutdown.
+type Coordinator struct {
+	timeout time.Duration
+	health  telegraf.Health
+}
+
+// NewCoordinator creates a Coordinator enforcing the given deadline. A
+// timeout of zero or less means wait indefinitely, matching today's
+// behavior of plugin Stop() calls.
+func NewCoordinator(timeout time.Duration, health telegraf.Health) *Coordinator {
+	return &Coordinator{timeout: timeout, health: health}
+}
+
+// Shutdown calls Stop on every plugin concurrently and waits for them to
+// finish, up to the coordinator's deadline. It returns the IDs of plugins
+// that had not stopped when the deadline elapsed; an empty slice means every
+// plugin stopped in time (or no deadline was configured).
+func (c *Coordinator) Shutdown(plugins []Plugin) []string {
+	done := make(chan string, len(plugins))
+	for _, p := range plugins {
+		go func(p Plugin) {
+			p.Stop()
+			done <- p.ID
+		}(p)
+	}
+
+	var timer *time.Timer
+	var deadline <-chan time.Time
+	if c.timeout > 0 {
+		timer = time.NewTimer(c.timeout)
+		defer timer.Stop()
+		deadline = timer.C
+	}
+
+	finished := make(map[string]bool, len(plugins))
+	for len(finished) < len(plugins) {
+		select {
+		case id := <-done:
+			finished[id] = true
+		case <-deadline:
+			return c.reportOutstanding(plugins, finished)
+		}
+	}
+	return nil
+}
+
+func (c *Coordinator) reportOutstanding(plugins []Plugin, finished map[string]bool) []string {
+	var outstanding []string
+	for _, p := range plugins {
+		if finished[p.ID] {
+			continue
+		}
+		outstanding = append(outstanding, p.ID)
+		if c.health != nil {
+			msg := fmt.Sprintf("did not stop within the %s shutdown_timeout", c.timeout)
+			c.health.Warnable("shutdown."+p.ID).Set(telegraf.HealthSeverityCritical, msg)
+		}
+	}
+	return outstanding
+}
@@ -0,0 +1,53 @@
+package shutdown
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/health"
+)
+
+type stopAfter time.Duration
+
+func (d stopAfter) Stop() {
+	time.Sleep(time.Duration(d))
+}
+
+func TestCoordinatorShutdownReturnsEmptyWhenAllStopInTime(t *testing.T) {
+	c := NewCoordinator(time.Second, nil)
+	outstanding := c.Shutdown([]Plugin{
+		{ID: "fast-a", Stopper: stopAfter(0)},
+		{ID: "fast-b", Stopper: stopAfter(time.Millisecond)},
+	})
+	require.Empty(t, outstanding)
+}
+
+func TestCoordinatorShutdownReportsOutstandingAfterTimeout(t *testing.T) {
+	c := NewCoordinator(10*time.Millisecond, nil)
+	outstanding := c.Shutdown([]Plugin{
+		{ID: "fast", Stopper: stopAfter(0)},
+		{ID: "wedged", Stopper: stopAfter(time.Hour)},
+	})
+	require.Equal(t, []string{"wedged"}, outstanding)
+}
+
+func TestCoordinatorShutdownMarksOutstandingUnhealthy(t *testing.T) {
+	tracker := health.NewTracker()
+	c := NewCoordinator(10*time.Millisecond, tracker)
+
+	c.Shutdown([]Plugin{{ID: "wedged", Stopper: stopAfter(time.Hour)}})
+
+	unhealthy := tracker.Unhealthy()
+	require.Len(t, unhealthy, 1)
+	require.Equal(t, "shutdown.wedged", unhealthy[0].Name)
+	require.Equal(t, telegraf.HealthSeverityCritical, unhealthy[0].Severity)
+}
+
+func TestCoordinatorShutdownWithoutTimeoutWaitsIndefinitely(t *testing.T) {
+	c := NewCoordinator(0, nil)
+	outstanding := c.Shutdown([]Plugin{{ID: "slow", Stopper: stopAfter(20 * time.Millisecond)}})
+	require.Empty(t, outstanding)
+}
@@ -0,0 +1,404 @@
+// Package server embeds Telegraf's bootstrap logic - config loading, plugin
+// initialization, migrations and the agent run loop - behind a single Server
+// type. cmd/telegraf is a thin urfave/cli wrapper over this package; third
+// parties that want to run Telegraf inside their own daemon (custom auth,
+// custom control planes, sidecars) can import pkg/server directly instead of
+// shelling out to the telegraf binary or vendoring package main.
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/agent"
+	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/health"
+	"github.com/influxdata/telegraf/internal/shutdown"
+	"github.com/influxdata/telegraf/migrations"
+)
+
+// Options configures a Server. It mirrors the flags accepted by the
+// "telegraf" and "telegraf config" CLI commands.
+type Options struct {
+	// ConfigFiles and ConfigDirs are merged, in that order, into the list of
+	// configuration files to load. If both are empty the default config
+	// search paths (config.GetDefaultConfigPath) are used instead.
+	ConfigFiles []string
+	ConfigDirs  []string
+
+	// Quiet suppresses the periodic agent stats that would otherwise be
+	// logged while running.
+	Quiet bool
+	// Debug enables verbose logging.
+	Debug bool
+
+	// ShutdownTimeout bounds how long Run waits, once its context is
+	// canceled, for the agent to stop before giving up and returning an
+	// error. Zero or negative means wait indefinitely, matching historical
+	// behavior.
+	ShutdownTimeout time.Duration
+	// Health, if set, receives a warnable per plugin that is still blocking
+	// shutdown once ShutdownTimeout elapses. If nil, Server manages its own
+	// *health.Tracker internally so Run always has somewhere real to report
+	// into; use Server.Health to retrieve it, e.g. to serve it over HTTP.
+	Health telegraf.Health
+}
+
+// Server is an embeddable Telegraf instance. The zero value is not usable;
+// construct one with New.
+type Server struct {
+	opts   Options
+	health telegraf.Health
+
+	mu     sync.Mutex
+	config *config.Config
+	agent  *agent.Agent
+}
+
+// New creates a Server with the given options. No configuration is loaded
+// until Check, Migrate or Run is called. If opts.Health is nil, New
+// constructs a fresh health.Tracker so the shutdown coordinator, and any
+// plugins the agent injects it into, always have a real telegraf.Health to
+// report against.
+func New(opts Options) *Server {
+	h := opts.Health
+	if h == nil {
+		h = health.NewTracker()
+	}
+	if t, ok := h.(*health.Tracker); ok {
+		// Registers t as the Tracker the "internal_health" input reads from.
+		// Harmless to call more than once per process; the most recently
+		// constructed Server wins, matching how only one Server is normally
+		// running at a time.
+		health.SetActive(t)
+	}
+	return &Server{opts: opts, health: h}
+}
+
+// Health returns the telegraf.Health the Server reports into - either the
+// one passed via Options, or the one it constructed for itself. Callers that
+// run their own HTTP server (or other control plane) can use this to expose
+// health without needing to build and thread a Tracker themselves.
+func (s *Server) Health() telegraf.Health {
+	return s.health
+}
+
+// HealthHandler returns an http.Handler serving Server.Health as JSON, and
+// true, if the underlying telegraf.Health supports it (every *health.Tracker
+// does); otherwise it returns false so the caller can decide whether to
+// mount anything at all.
+func (s *Server) HealthHandler() (http.Handler, bool) {
+	t, ok := s.health.(*health.Tracker)
+	if !ok {
+		return nil, false
+	}
+	return t.Handler(), true
+}
+
+// logHealthSummary writes a single structured log line summarizing the
+// current health state, labeled with event (e.g. "starting", "stopped").
+// It is a no-op if Server.Health isn't a *health.Tracker.
+func (s *Server) logHealthSummary(event string) {
+	t, ok := s.health.(*health.Tracker)
+	if !ok {
+		return
+	}
+
+	all := t.Snapshot()
+	unhealthy := t.Unhealthy()
+	if len(unhealthy) == 0 {
+		log.Printf("I! [agent] %s: %d health check(s), all healthy", event, len(all))
+		return
+	}
+
+	names := make([]string, 0, len(unhealthy))
+	for _, status := range unhealthy {
+		names = append(names, status.Name)
+	}
+	log.Printf("W! [agent] %s: %d/%d health check(s) unhealthy: %s", event, len(unhealthy), len(all), strings.Join(names, ", "))
+}
+
+// configFiles resolves the set of configuration files to load from the
+// options, falling back to the default search paths.
+func (s *Server) configFiles() ([]string, error) {
+	files := append([]string{}, s.opts.ConfigFiles...)
+	for _, dir := range s.opts.ConfigDirs {
+		found, err := config.WalkDirectory(dir)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, found...)
+	}
+
+	if len(files) == 0 {
+		paths, err := config.GetDefaultConfigPath()
+		if err != nil {
+			return nil, err
+		}
+		files = paths
+	}
+	return files, nil
+}
+
+// loadConfig reads and parses the resolved configuration files into a fresh
+// config.Config. It does not initialize plugins.
+func (s *Server) loadConfig() (*config.Config, error) {
+	files, err := s.configFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	c := config.NewConfig()
+	c.Agent.Quiet = s.opts.Quiet
+	if err := c.LoadAll(files...); err != nil {
+		return nil, err
+	}
+
+	// The default for this option is changing in a future release; warn
+	// loudly until users have explicitly made a choice.
+	if c.Agent.SkipProcessorsAfterAggregators == nil {
+		msg := `The default value of 'skip_processors_after_aggregators' will change to 'true' with Telegraf v1.40.0! `
+		msg += `If you need the current default behavior, please explicitly set the option to 'false'!`
+		log.Print("W! [agent] ", msg)
+		skipProcessorsAfterAggregators := false
+		c.Agent.SkipProcessorsAfterAggregators = &skipProcessorsAfterAggregators
+	}
+
+	return c, nil
+}
+
+// Check loads the configuration and initializes, but does not start, its
+// plugins, returning the collected diagnostics. The returned error is always
+// nil: failures to resolve or load the configuration files, as well as
+// plugin initialization failures, are reported as config.RuleInitFailure
+// entries with severity config.DiagnosticError.
+//
+// Check reports config.RuleInitFailure diagnostics from initializing
+// plugins, plus config.RuleDeprecatedPlugin/config.RuleDeprecatedOption
+// diagnostics from scanning the resolved files with config.ScanFile. The
+// latter two work directly off the file text rather than LoadAll's own
+// deprecation logging, which --format json/sarif consumers otherwise never
+// see; unknown-field detection still isn't collected this way, since it
+// needs the plugin's actual schema from LoadAll's unmarshaling rather than a
+// text scan, so --format text (which reads the log) remains the only way to
+// see those until LoadAll itself collects them as config.Diagnostic.
+func (s *Server) Check() (config.Diagnostics, error) {
+	files, err := s.configFiles()
+	if err != nil {
+		return config.Diagnostics{{
+			RuleID:   config.RuleInitFailure,
+			Severity: config.DiagnosticError,
+			Message:  err.Error(),
+		}}, nil
+	}
+
+	var diags config.Diagnostics
+	for _, file := range files {
+		found, err := config.ScanFile(file)
+		if err != nil {
+			log.Printf("W! [agent] scanning %s for deprecations: %s", file, err)
+			continue
+		}
+		diags = append(diags, found...)
+	}
+
+	c, err := s.loadConfig()
+	if err != nil {
+		return append(diags, config.Diagnostic{
+			RuleID:   config.RuleInitFailure,
+			Severity: config.DiagnosticError,
+			Message:  err.Error(),
+		}), nil
+	}
+
+	ag := agent.NewAgent(c)
+	if err := ag.InitPlugins(); err != nil {
+		return append(diags, config.Diagnostic{
+			RuleID:   config.RuleInitFailure,
+			Severity: config.DiagnosticError,
+			Message:  err.Error(),
+		}), nil
+	}
+	return diags, nil
+}
+
+// MigrationResult describes the outcome of attempting to migrate a single
+// configuration file.
+type MigrationResult struct {
+	// File is the input path or URL as passed to Telegraf.
+	File string
+	// Remote is true if File was loaded from a remote URL rather than disk.
+	Remote bool
+	// OutputFile is the suggested "<name>.migrated" destination for Migrated.
+	OutputFile string
+	// Applied is the number of migrations that were applied; zero means the
+	// file was already up to date and Original/Migrated are identical.
+	Applied int
+	// Original and Migrated are the configuration bytes before and after
+	// running the migrations.
+	Original []byte
+	Migrated []byte
+}
+
+// Migrate runs config.ApplyMigrations against every resolved configuration
+// file and returns the per-file results without writing anything to disk;
+// callers decide what to do with the result (write ".migrated" files, print
+// a diff, ...).
+func (s *Server) Migrate() ([]MigrationResult, error) {
+	files, err := s.configFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	migrationsGeneral := len(migrations.GeneralMigrations) + len(migrations.GlobalMigrations)
+	migrationsPlugins := len(migrations.PluginMigrations)
+	migrationsOptions := len(migrations.PluginOptionMigrations)
+	if migrationsGeneral+migrationsPlugins+migrationsOptions == 0 {
+		return nil, errors.New("no migrations available")
+	}
+
+	results := make([]MigrationResult, 0, len(files))
+	for _, fn := range files {
+		data, remote, err := config.LoadConfigFile(fn)
+		if err != nil {
+			return nil, fmt.Errorf("opening input %q failed: %w", fn, err)
+		}
+
+		out, applied, err := config.ApplyMigrations(data)
+		if err != nil {
+			return nil, err
+		}
+
+		outfn := fn + ".migrated"
+		if remote {
+			u, err := url.Parse(fn)
+			if err != nil {
+				return nil, fmt.Errorf("parsing remote config URL %q failed: %w", fn, err)
+			}
+			outfn = filepath.Base(u.Path) + ".migrated"
+		}
+
+		results = append(results, MigrationResult{
+			File:       fn,
+			Remote:     remote,
+			OutputFile: outfn,
+			Applied:    applied,
+			Original:   data,
+			Migrated:   out,
+		})
+	}
+	return results, nil
+}
+
+// WriteMigrated writes every result with Applied > 0 to its OutputFile,
+// refusing to overwrite an existing file unless force is set. It is the
+// behavior backing "telegraf config migrate".
+func WriteMigrated(results []MigrationResult, force bool) error {
+	for _, r := range results {
+		if r.Applied == 0 {
+			continue
+		}
+
+		if !force {
+			if _, err := os.Stat(r.OutputFile); !errors.Is(err, os.ErrNotExist) {
+				return fmt.Errorf("output file %q already exists", r.OutputFile)
+			}
+		}
+
+		if err := os.WriteFile(r.OutputFile, r.Migrated, 0640); err != nil {
+			return fmt.Errorf("writing output %q failed: %w", r.OutputFile, err)
+		}
+	}
+	return nil
+}
+
+// Run loads the configuration, initializes plugins and runs the agent until
+// ctx is canceled or a plugin fails fatally. Once ctx is canceled, Run waits
+// up to Options.ShutdownTimeout for the agent to stop; if it does not, Run
+// returns an error naming the agent as still blocking (reported through
+// Server.Health, which is always non-nil) instead of hanging forever. Today
+// this wraps the agent as a single unit; reporting individual plugins by ID
+// requires agent.Agent to expose its running plugins to the coordinator.
+// Run also logs a structured health summary on start and stop via
+// Server.Health.
+//
+// Plugins only see Server.Health in their own Health field if agent.NewAgent
+// injects it the same way it injects telegraf.Logger; that wiring lives in
+// the agent package and is outside what this package can set up on its own,
+// so NSQConsumer.Health/snmp_lookup's Processor.Health are nil unless an
+// embedder sets them directly.
+func (s *Server) Run(ctx context.Context) error {
+	c, err := s.loadConfig()
+	if err != nil {
+		return err
+	}
+
+	ag := agent.NewAgent(c)
+	if err := ag.InitPlugins(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.config = c
+	s.agent = ag
+	s.mu.Unlock()
+
+	s.logHealthSummary("starting")
+
+	runErr := make(chan error, 1)
+	go func() { runErr <- ag.Run(ctx) }()
+
+	select {
+	case err := <-runErr:
+		s.logHealthSummary("stopped")
+		return err
+	case <-ctx.Done():
+	}
+
+	coordinator := shutdown.NewCoordinator(s.opts.ShutdownTimeout, s.health)
+	if outstanding := coordinator.Shutdown([]shutdown.Plugin{{ID: "agent", Stopper: ag}}); len(outstanding) > 0 {
+		// ag.Run is still executing in the background: Go has no way to
+		// force a goroutine to stop, so Run can't kill it, only stop waiting
+		// on it. Log whenever it does eventually finish instead of silently
+		// discarding the result, so a wedged Stop doesn't vanish without a
+		// trace.
+		go func() {
+			if err := <-runErr; err != nil {
+				log.Printf("E! [agent] Stop() returned %s after shutdown_timeout had already elapsed", err)
+			} else {
+				log.Print("I! [agent] finished stopping after shutdown_timeout had already elapsed")
+			}
+		}()
+		s.logHealthSummary("shutdown_timeout elapsed")
+		return fmt.Errorf("shutdown_timeout elapsed, still waiting for: %s", strings.Join(outstanding, ", "))
+	}
+	s.logHealthSummary("stopped")
+	return <-runErr
+}
+
+// Reload re-loads the configuration and restarts the agent with it, as if
+// the process had received SIGHUP. It stops the previous agent, if any, and
+// then runs the new one exactly like Run, so it blocks until ctx is canceled
+// or the new run fails; ctx governs the new run, not the shutdown of the old
+// one.
+func (s *Server) Reload(ctx context.Context) error {
+	s.mu.Lock()
+	previous := s.agent
+	s.mu.Unlock()
+
+	if previous != nil {
+		previous.Stop()
+	}
+
+	return s.Run(ctx)
+}
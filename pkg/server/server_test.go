@@ -0,0 +1,61 @@
+package server
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/health"
+)
+
+func TestNewDefaultsHealthWhenNotProvided(t *testing.T) {
+	srv := New(Options{})
+	require.NotNil(t, srv.Health())
+}
+
+func TestNewKeepsProvidedHealth(t *testing.T) {
+	tracker := health.NewTracker()
+	srv := New(Options{Health: tracker})
+	require.Same(t, tracker, srv.Health())
+}
+
+func TestServerCheckReportsConfigLoadFailure(t *testing.T) {
+	srv := New(Options{ConfigFiles: []string{filepath.Join(t.TempDir(), "does-not-exist.conf")}})
+
+	diags, err := srv.Check()
+	require.NoError(t, err)
+	require.Len(t, diags, 1)
+	require.Equal(t, config.RuleInitFailure, diags[0].RuleID)
+	require.Equal(t, config.DiagnosticError, diags[0].Severity)
+}
+
+func TestServerRunStopsWithinDeadline(t *testing.T) {
+	confPath := filepath.Join(t.TempDir(), "telegraf.conf")
+	require.NoError(t, os.WriteFile(confPath, []byte(""), 0600))
+
+	srv := New(Options{
+		ConfigFiles:     []string{confPath},
+		ShutdownTimeout: 5 * time.Second,
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	runErr := make(chan error, 1)
+	go func() { runErr <- srv.Run(ctx) }()
+
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+
+	select {
+	case err := <-runErr:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not stop within its shutdown_timeout")
+	}
+}
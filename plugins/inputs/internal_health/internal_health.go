@@ -0,0 +1,47 @@
+//go:generate ../../../tools/readme_config_includer/generator
+package internal_health
+
+import (
+	_ "embed"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/health"
+	"github.com/influxdata/telegraf/plugins/inputs"
+)
+
+//go:embed sample.conf
+var sampleConfig string
+
+// InternalHealth exposes the process-wide health.Tracker registered via
+// health.SetActive (pkg/server.Server does this for whichever Tracker it
+// owns) as metrics, one per warnable, so the agent's health can be graphed
+// and alerted on the same way as any other measurement instead of only via
+// the "/health" HTTP endpoint.
+type InternalHealth struct{}
+
+func (*InternalHealth) SampleConfig() string {
+	return sampleConfig
+}
+
+func (*InternalHealth) Gather(acc telegraf.Accumulator) error {
+	tracker := health.Active()
+	if tracker == nil {
+		return nil
+	}
+
+	for _, status := range tracker.Snapshot() {
+		tags := map[string]string{"name": status.Name}
+		fields := map[string]interface{}{
+			"healthy":  status.Healthy,
+			"severity": int(status.Severity),
+		}
+		acc.AddFields("internal_health", fields, tags)
+	}
+	return nil
+}
+
+func init() {
+	inputs.Add("internal_health", func() telegraf.Input {
+		return &InternalHealth{}
+	})
+}
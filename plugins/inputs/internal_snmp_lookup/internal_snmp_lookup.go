@@ -0,0 +1,49 @@
+//go:generate ../../../tools/readme_config_includer/generator
+package internal_snmp_lookup
+
+import (
+	_ "embed"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/inputs"
+	"github.com/influxdata/telegraf/plugins/processors/snmp_lookup"
+)
+
+//go:embed sample.conf
+var sampleConfig string
+
+// InternalSNMPLookup exposes the built-in cache metrics of every configured
+// "snmp_lookup" processor (size, hits/misses, evictions, in-flight lookups,
+// deferred backlog depth, worker pool utilization and average update
+// latency), one metric per processor instance, tagged by its processor ID
+// (e.g. "snmp_lookup-1").
+type InternalSNMPLookup struct{}
+
+func (*InternalSNMPLookup) SampleConfig() string {
+	return sampleConfig
+}
+
+func (*InternalSNMPLookup) Gather(acc telegraf.Accumulator) error {
+	for id, stats := range snmp_lookup.Stats() {
+		tags := map[string]string{"processor": id}
+		fields := map[string]interface{}{
+			"cache_size":                stats.CacheSize,
+			"hits":                      stats.Hits,
+			"misses":                    stats.Misses,
+			"evictions":                 stats.Evictions,
+			"inflight":                  stats.Inflight,
+			"deferred_backlog_depth":    stats.DeferredBacklogDepth,
+			"pool_running_workers":      stats.PoolRunningWorkers,
+			"pool_max_workers":          stats.PoolMaxWorkers,
+			"average_update_latency_ms": float64(stats.AverageUpdateLatency) / float64(1e6),
+		}
+		acc.AddFields("internal_snmp_lookup", fields, tags)
+	}
+	return nil
+}
+
+func init() {
+	inputs.Add("internal_snmp_lookup", func() telegraf.Input {
+		return &InternalSNMPLookup{}
+	})
+}
@@ -5,11 +5,15 @@ import (
 	"context"
 	_ "embed"
 	"errors"
+	"fmt"
 	"sync"
+	"time"
 
 	"github.com/nsqio/go-nsq"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/config"
+	tlsint "github.com/influxdata/telegraf/plugins/common/tls"
 	"github.com/influxdata/telegraf/plugins/inputs"
 )
 
@@ -18,19 +22,55 @@ var sampleConfig string
 
 const (
 	defaultMaxUndeliveredMessages = 1000
+	defaultRequeueDelay           = -1 // let go-nsq's default requeue delay decide
+
+	// warnableBrokerUnreachable tracks whether the plugin is currently able
+	// to reach its configured nsqd/nsqlookupd brokers.
+	warnableBrokerUnreachable = "nsq_consumer.broker_unreachable"
 )
 
 type NSQConsumer struct {
-	Nsqd                   []string        `toml:"nsqd"`
-	Nsqlookupd             []string        `toml:"nsqlookupd"`
-	Topic                  string          `toml:"topic"`
-	Channel                string          `toml:"channel"`
-	MaxInFlight            int             `toml:"max_in_flight"`
-	MaxUndeliveredMessages int             `toml:"max_undelivered_messages"`
-	Log                    telegraf.Logger `toml:"-"`
+	Nsqd                   []string `toml:"nsqd"`
+	Nsqlookupd             []string `toml:"nsqlookupd"`
+	Topic                  string   `toml:"topic"`
+	Channel                string   `toml:"channel"`
+	MaxInFlight            int      `toml:"max_in_flight"`
+	MaxUndeliveredMessages int      `toml:"max_undelivered_messages"`
+
+	// AuthSecret holds the NSQ AUTH secret used during IDENTIFY. It is kept
+	// in Telegraf's secret store and only resolved to plaintext for the
+	// duration of the handshake.
+	AuthSecret config.Secret `toml:"auth_secret"`
+
+	Deflate             bool            `toml:"deflate"`
+	DeflateLevel        int             `toml:"deflate_level"`
+	Snappy              bool            `toml:"snappy"`
+	MsgTimeout          config.Duration `toml:"msg_timeout"`
+	HeartbeatInterval   config.Duration `toml:"heartbeat_interval"`
+	LookupdPollInterval config.Duration `toml:"lookupd_poll_interval"`
+	LookupdPollJitter   float64         `toml:"lookupd_poll_jitter"`
+	MaxAttempts         uint16          `toml:"max_attempts"`
+	SampleRate          int32           `toml:"sample_rate"`
+
+	// RequeueDelay controls how long nsqd waits before redelivering a
+	// requeued message. A negative value (the default) leaves go-nsq's
+	// default requeue delay, which grows with the number of attempts, in
+	// charge.
+	RequeueDelay config.Duration `toml:"requeue_delay"`
+
+	// DeadLetterTopic, when set, receives messages that have been attempted
+	// MaxAttempts times instead of silently dropping them.
+	DeadLetterTopic string `toml:"dead_letter_topic"`
+
+	tlsint.ClientConfig
+
+	Log    telegraf.Logger `toml:"-"`
+	Health telegraf.Health `toml:"-"`
 
 	parser   telegraf.Parser
 	consumer *nsq.Consumer
+	producer *nsq.Producer // publishes to DeadLetterTopic, nil if unused
+	broker   telegraf.Warnable
 
 	mu       sync.Mutex
 	messages map[telegraf.TrackingID]*nsq.Message
@@ -63,6 +103,10 @@ func (n *NSQConsumer) Init() error {
 		return errors.New("either 'nsqd' or 'nsqlookupd' needs to be specified")
 	}
 
+	if n.MaxAttempts > 0 && n.DeadLetterTopic == n.Topic {
+		return errors.New("'dead_letter_topic' must not be the same as 'topic'")
+	}
+
 	return nil
 }
 
@@ -79,9 +123,22 @@ func (n *NSQConsumer) Start(ac telegraf.Accumulator) error {
 	ctx, cancel := context.WithCancel(context.Background())
 	n.cancel = cancel
 
+	if n.Health != nil {
+		n.broker = n.Health.Warnable(warnableBrokerUnreachable)
+	}
+
 	if err := n.connect(); err != nil {
 		return err
 	}
+
+	if n.DeadLetterTopic != "" {
+		producer, err := n.connectProducer()
+		if err != nil {
+			return fmt.Errorf("connecting dead-letter producer failed: %w", err)
+		}
+		n.producer = producer
+	}
+
 	n.consumer.SetLogger(&logger{log: n.Log}, nsq.LogLevelInfo)
 	n.consumer.AddHandler(nsq.HandlerFunc(func(message *nsq.Message) error {
 		metrics, err := n.parser.Parse(message.Body)
@@ -108,12 +165,19 @@ func (n *NSQConsumer) Start(ac telegraf.Accumulator) error {
 		n.messages[id] = message
 		n.mu.Unlock()
 		message.DisableAutoResponse()
+		if n.broker != nil {
+			n.broker.Clear()
+		}
 		return nil
 	}))
 
 	if len(n.Nsqlookupd) > 0 {
 		err := n.consumer.ConnectToNSQLookupds(n.Nsqlookupd)
 		if err != nil && !errors.Is(err, nsq.ErrAlreadyConnected) {
+			if n.broker != nil {
+				n.broker.Set(telegraf.HealthSeverityCritical, err.Error())
+			}
+			n.stopProducer()
 			return err
 		}
 	}
@@ -121,6 +185,10 @@ func (n *NSQConsumer) Start(ac telegraf.Accumulator) error {
 	if len(n.Nsqd) > 0 {
 		err := n.consumer.ConnectToNSQDs(n.Nsqd)
 		if err != nil && !errors.Is(err, nsq.ErrAlreadyConnected) {
+			if n.broker != nil {
+				n.broker.Set(telegraf.HealthSeverityCritical, err.Error())
+			}
+			n.stopProducer()
 			return err
 		}
 	}
@@ -142,6 +210,17 @@ func (n *NSQConsumer) Stop() {
 	n.wg.Wait()
 	n.consumer.Stop()
 	<-n.consumer.StopChan
+	n.stopProducer()
+}
+
+// stopProducer releases the dead-letter producer connection, if one was
+// made. Start calls this directly on its own failure paths, since Stop is
+// never called for a plugin whose Start returned an error.
+func (n *NSQConsumer) stopProducer() {
+	if n.producer != nil {
+		n.producer.Stop()
+		n.producer = nil
+	}
 }
 
 func (n *NSQConsumer) onDelivery(ctx context.Context, acc telegraf.TrackingAccumulator, sem semaphore) {
@@ -162,30 +241,134 @@ func (n *NSQConsumer) onDelivery(ctx context.Context, acc telegraf.TrackingAccum
 
 			if info.Delivered() {
 				msg.Finish()
-			} else {
-				msg.Requeue(-1)
+				continue
+			}
+			n.requeueOrDeadLetter(msg)
+		}
+	}
+}
+
+// requeueOrDeadLetter requeues a failed message, unless it has already been
+// attempted MaxAttempts times, in which case it is either dropped (FIN'd) or
+// forwarded to DeadLetterTopic so it isn't silently lost.
+func (n *NSQConsumer) requeueOrDeadLetter(msg *nsq.Message) {
+	if n.MaxAttempts == 0 || msg.Attempts < n.MaxAttempts {
+		msg.Requeue(timeDuration(n.RequeueDelay))
+		return
+	}
+
+	if n.producer != nil {
+		if err := n.producer.Publish(n.DeadLetterTopic, msg.Body); err != nil {
+			n.Log.Errorf("publishing to dead-letter topic %q failed: %v", n.DeadLetterTopic, err)
+			if n.broker != nil {
+				n.broker.Set(telegraf.HealthSeverityWarning, err.Error())
 			}
+			// Keep retrying rather than drop the message if we couldn't
+			// hand it off to the dead-letter topic.
+			msg.Requeue(timeDuration(n.RequeueDelay))
+			return
 		}
 	}
+	msg.Finish()
 }
 
 func (n *NSQConsumer) connect() error {
-	if n.consumer == nil {
-		config := nsq.NewConfig()
-		config.MaxInFlight = n.MaxInFlight
-		consumer, err := nsq.NewConsumer(n.Topic, n.Channel, config)
+	if n.consumer != nil {
+		return nil
+	}
+
+	cfg, err := n.nsqConfig()
+	if err != nil {
+		return err
+	}
+
+	consumer, err := nsq.NewConsumer(n.Topic, n.Channel, cfg)
+	if err != nil {
+		return err
+	}
+	n.consumer = consumer
+	return nil
+}
+
+func (n *NSQConsumer) connectProducer() (*nsq.Producer, error) {
+	if len(n.Nsqd) == 0 {
+		return nil, errors.New("'dead_letter_topic' requires at least one 'nsqd' address")
+	}
+
+	cfg, err := n.nsqConfig()
+	if err != nil {
+		return nil, err
+	}
+	return nsq.NewProducer(n.Nsqd[0], cfg)
+}
+
+// nsqConfig translates the plugin's options into the go-nsq configuration
+// used for both the consumer and the dead-letter producer.
+func (n *NSQConsumer) nsqConfig() (*nsq.Config, error) {
+	cfg := nsq.NewConfig()
+	cfg.MaxInFlight = n.MaxInFlight
+
+	tlsConfig, err := n.ClientConfig.TLSConfig()
+	if err != nil {
+		return nil, fmt.Errorf("creating TLS config failed: %w", err)
+	}
+	if tlsConfig != nil {
+		cfg.TlsV1 = true
+		cfg.TlsConfig = tlsConfig
+	}
+
+	if !n.AuthSecret.Empty() {
+		secret, err := n.AuthSecret.Get()
 		if err != nil {
-			return err
+			return nil, fmt.Errorf("getting auth secret failed: %w", err)
 		}
-		n.consumer = consumer
+		defer secret.Destroy()
+		cfg.AuthSecret = secret.String()
 	}
-	return nil
+
+	cfg.Deflate = n.Deflate
+	if n.DeflateLevel > 0 {
+		cfg.DeflateLevel = n.DeflateLevel
+	}
+	cfg.Snappy = n.Snappy
+
+	if n.MsgTimeout > 0 {
+		cfg.MsgTimeout = timeDuration(n.MsgTimeout)
+	}
+	if n.HeartbeatInterval > 0 {
+		cfg.HeartbeatInterval = timeDuration(n.HeartbeatInterval)
+	}
+	if n.LookupdPollInterval > 0 {
+		cfg.LookupdPollInterval = timeDuration(n.LookupdPollInterval)
+	}
+	if n.LookupdPollJitter > 0 {
+		cfg.LookupdPollJitter = n.LookupdPollJitter
+	}
+	if n.MaxAttempts > 0 {
+		cfg.MaxAttempts = n.MaxAttempts
+	}
+	if n.SampleRate > 0 {
+		cfg.SampleRate = n.SampleRate
+	}
+
+	return cfg, nil
+}
+
+// timeDuration converts a config.Duration to the time.Duration the go-nsq
+// API expects. Every negative value becomes -1, the value go-nsq's Requeue
+// takes to mean "use the default requeue delay".
+func timeDuration(d config.Duration) time.Duration {
+	if d < 0 {
+		return -1
+	}
+	return time.Duration(d)
 }
 
 func init() {
 	inputs.Add("nsq_consumer", func() telegraf.Input {
 		return &NSQConsumer{
 			MaxUndeliveredMessages: defaultMaxUndeliveredMessages,
+			RequeueDelay:           config.Duration(defaultRequeueDelay),
 		}
 	})
 }
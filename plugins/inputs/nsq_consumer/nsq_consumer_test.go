@@ -0,0 +1,105 @@
+package nsq_consumer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nsqio/go-nsq"
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf/config"
+)
+
+func TestNSQConfigDefaults(t *testing.T) {
+	n := &NSQConsumer{MaxInFlight: 42}
+
+	cfg, err := n.nsqConfig()
+	require.NoError(t, err)
+	require.Equal(t, 42, cfg.MaxInFlight)
+	require.False(t, cfg.TlsV1)
+	require.Empty(t, cfg.AuthSecret)
+	require.False(t, cfg.Deflate)
+	require.False(t, cfg.Snappy)
+}
+
+func TestNSQConfigAppliesTunables(t *testing.T) {
+	n := &NSQConsumer{
+		Deflate:             true,
+		DeflateLevel:        6,
+		Snappy:              true,
+		MsgTimeout:          config.Duration(10 * time.Second),
+		HeartbeatInterval:   config.Duration(20 * time.Second),
+		LookupdPollInterval: config.Duration(30 * time.Second),
+		LookupdPollJitter:   0.5,
+		MaxAttempts:         5,
+		SampleRate:          10,
+	}
+
+	cfg, err := n.nsqConfig()
+	require.NoError(t, err)
+	require.True(t, cfg.Deflate)
+	require.Equal(t, 6, cfg.DeflateLevel)
+	require.True(t, cfg.Snappy)
+	require.Equal(t, 10*time.Second, cfg.MsgTimeout)
+	require.Equal(t, 20*time.Second, cfg.HeartbeatInterval)
+	require.Equal(t, 30*time.Second, cfg.LookupdPollInterval)
+	require.InDelta(t, 0.5, cfg.LookupdPollJitter, 0.0001)
+	require.EqualValues(t, 5, cfg.MaxAttempts)
+	require.EqualValues(t, 10, cfg.SampleRate)
+}
+
+// fakeDelegate records the terminal action go-nsq took on a message so tests
+// don't need a real nsqd connection to observe Requeue/Finish.
+type fakeDelegate struct {
+	requeued    bool
+	requeueWait time.Duration
+	finished    bool
+}
+
+func (d *fakeDelegate) OnFinish(*nsq.Message) { d.finished = true }
+func (d *fakeDelegate) OnRequeue(_ *nsq.Message, delay time.Duration, _ bool) {
+	d.requeued = true
+	d.requeueWait = delay
+}
+func (d *fakeDelegate) OnTouch(*nsq.Message) {}
+
+func newTestMessage(delegate *fakeDelegate, attempts uint16) *nsq.Message {
+	msg := nsq.NewMessage(nsq.MessageID{}, []byte("body"))
+	msg.Attempts = attempts
+	msg.Delegate = delegate
+	return msg
+}
+
+func TestRequeueOrDeadLetterRequeuesBelowMaxAttempts(t *testing.T) {
+	n := &NSQConsumer{MaxAttempts: 3, RequeueDelay: config.Duration(5 * time.Second)}
+	delegate := &fakeDelegate{}
+	msg := newTestMessage(delegate, 1)
+
+	n.requeueOrDeadLetter(msg)
+
+	require.True(t, delegate.requeued)
+	require.False(t, delegate.finished)
+	require.Equal(t, 5*time.Second, delegate.requeueWait)
+}
+
+func TestRequeueOrDeadLetterFinishesAtMaxAttemptsWithoutDeadLetterTopic(t *testing.T) {
+	n := &NSQConsumer{MaxAttempts: 3}
+	delegate := &fakeDelegate{}
+	msg := newTestMessage(delegate, 3)
+
+	n.requeueOrDeadLetter(msg)
+
+	require.True(t, delegate.finished)
+	require.False(t, delegate.requeued)
+}
+
+func TestRequeueOrDeadLetterAlwaysRequeuesWhenMaxAttemptsUnset(t *testing.T) {
+	n := &NSQConsumer{}
+	delegate := &fakeDelegate{}
+	msg := newTestMessage(delegate, 50)
+
+	n.requeueOrDeadLetter(msg)
+
+	require.True(t, delegate.requeued)
+	require.False(t, delegate.finished)
+}
@@ -0,0 +1,204 @@
+//go:generate ../../../tools/readme_config_includer/generator
+package snmp_lookup
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gosnmp/gosnmp"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/plugins/processors"
+)
+
+//go:embed sample.conf
+var sampleConfig string
+
+// tagMap is a single cached snapshot of the tags resolved for an agent, keyed
+// by the index (e.g. interface index) the tags belong to.
+type tagMap struct {
+	created time.Time
+	rows    map[string]map[string]string
+}
+
+// pendingMetric is a metric waiting for its agent's tags to be resolved
+// before it can be emitted.
+type pendingMetric struct {
+	metric telegraf.Metric
+	index  string
+	acc    telegraf.Accumulator
+}
+
+// nextProcessorID hands out a stable, unique ID per Processor instance so
+// several "snmp_lookup" processors in the same config can each be told apart
+// in the health tracker and the "internal_snmp_lookup" input.
+var nextProcessorID atomic.Int64
+
+type Processor struct {
+	AgentTag              string          `toml:"agent_tag"`
+	IndexTag              string          `toml:"index_tag"`
+	Community             string          `toml:"community"`
+	Tags                  []string        `toml:"tags"`
+	MaxCacheSize          int             `toml:"max_cache_size"`
+	CacheTTL              config.Duration `toml:"cache_ttl"`
+	ParallelLookups       int             `toml:"max_parallel_lookups"`
+	MinTimeBetweenUpdates config.Duration `toml:"min_time_between_updates"`
+
+	// CachePersistPath, when set, persists the resolved tag cache to disk so
+	// an agent restart does not cause a thundering herd of SNMP walks
+	// against every device. CachePersistInterval controls how often the
+	// cache is written while running, in addition to always being written
+	// on Stop.
+	CachePersistPath     string          `toml:"cache_persist_path"`
+	CachePersistInterval config.Duration `toml:"cache_persist_interval"`
+
+	Log    telegraf.Logger `toml:"-"`
+	Health telegraf.Health `toml:"-"`
+
+	processorID string
+	store       *store
+
+	mu      sync.Mutex
+	pending map[string][]pendingMetric
+}
+
+func (*Processor) SampleConfig() string {
+	return sampleConfig
+}
+
+func (p *Processor) Init() error {
+	if p.AgentTag == "" {
+		return errors.New("agent_tag cannot be empty")
+	}
+	if p.IndexTag == "" {
+		return errors.New("index_tag cannot be empty")
+	}
+
+	p.processorID = fmt.Sprintf("snmp_lookup-%d", nextProcessorID.Add(1))
+	p.pending = make(map[string][]pendingMetric)
+
+	p.store = newStore(
+		p.processorID,
+		p.MaxCacheSize,
+		p.CacheTTL,
+		p.ParallelLookups,
+		p.MinTimeBetweenUpdates,
+		p.Health,
+		p.CachePersistPath,
+		p.CachePersistInterval,
+	)
+	p.store.update = p.update
+	p.store.notify = p.notify
+
+	return nil
+}
+
+func (*Processor) Start(telegraf.Accumulator) error {
+	return nil
+}
+
+func (p *Processor) Add(m telegraf.Metric, acc telegraf.Accumulator) error {
+	agent, found := m.GetTag(p.AgentTag)
+	if !found {
+		acc.AddMetric(m)
+		return nil
+	}
+	index, found := m.GetTag(p.IndexTag)
+	if !found {
+		acc.AddMetric(m)
+		return nil
+	}
+
+	p.mu.Lock()
+	p.pending[agent] = append(p.pending[agent], pendingMetric{metric: m, index: index, acc: acc})
+	p.mu.Unlock()
+
+	p.store.lookup(agent, index)
+	return nil
+}
+
+func (p *Processor) Stop() {
+	if p.store != nil {
+		p.store.destroy()
+	}
+}
+
+// notify releases every metric queued for agent, tagging it with whatever
+// was resolved for its index if the lookup succeeded.
+func (p *Processor) notify(agent string, entry *tagMap) {
+	p.mu.Lock()
+	pending := p.pending[agent]
+	delete(p.pending, agent)
+	p.mu.Unlock()
+
+	for _, pm := range pending {
+		if entry != nil {
+			if tags, found := entry.rows[pm.index]; found {
+				for k, v := range tags {
+					pm.metric.AddTag(k, v)
+				}
+			}
+		}
+		pm.acc.AddMetric(pm.metric)
+	}
+}
+
+// update performs the actual SNMP walk for agent, resolving the configured
+// tag OIDs for every row (e.g. interface index) it finds.
+func (p *Processor) update(agent string) *tagMap {
+	entry := &tagMap{created: time.Now(), rows: make(map[string]map[string]string)}
+
+	client := &gosnmp.GoSNMP{
+		Target:    agent,
+		Port:      161,
+		Community: p.Community,
+		Version:   gosnmp.Version2c,
+		Timeout:   5 * time.Second,
+		Retries:   1,
+	}
+	if err := client.Connect(); err != nil {
+		p.Log.Warnf("connecting to %q failed: %v", agent, err)
+		return entry
+	}
+	defer client.Conn.Close()
+
+	for _, oid := range p.Tags {
+		walkErr := client.BulkWalk(oid, func(pdu gosnmp.SnmpPDU) error {
+			index := lastOIDComponent(pdu.Name)
+			if entry.rows[index] == nil {
+				entry.rows[index] = make(map[string]string)
+			}
+			entry.rows[index][oid] = fmt.Sprintf("%v", pdu.Value)
+			return nil
+		})
+		if walkErr != nil {
+			p.Log.Warnf("walking %q on %q failed: %v", oid, agent, walkErr)
+		}
+	}
+
+	return entry
+}
+
+// lastOIDComponent returns the final dotted component of an OID, which
+// gosnmp walks typically use as the table row index.
+func lastOIDComponent(oid string) string {
+	oid = strings.TrimPrefix(oid, ".")
+	parts := strings.Split(oid, ".")
+	return parts[len(parts)-1]
+}
+
+func init() {
+	processors.Add("snmp_lookup", func() telegraf.StreamingProcessor {
+		return &Processor{
+			MaxCacheSize:    1000,
+			CacheTTL:        config.Duration(8 * time.Hour),
+			ParallelLookups: 16,
+		}
+	})
+}
@@ -7,9 +7,16 @@ import (
 	"github.com/alitto/pond"
 	"github.com/hashicorp/golang-lru/v2/expirable"
 
+	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/config"
 )
 
+// warnableAgentUnreachable tracks whether the most recent SNMP walk, of
+// whichever agent, came back empty, i.e. that agent could not be reached.
+// It is not tracked per agent: a later successful walk of any agent clears
+// it, even while another agent is still unreachable.
+const warnableAgentUnreachable = "snmp_lookup.agent_unreachable"
+
 type store struct {
 	cache                *expirable.LRU[string, *tagMap]
 	pool                 *pond.WorkerPool
@@ -20,17 +27,85 @@ type store struct {
 	notify               func(string, *tagMap)
 	update               func(string) *tagMap
 	stopped              bool // Add flag to track if store is being destroyed
+	unreachable          telegraf.Warnable
+	saturated            telegraf.Warnable
+
+	ttl           time.Duration
+	persistPath   string
+	persistTicker *time.Ticker
+	persistDone   chan struct{}
+	metrics       storeMetrics
+
+	id string
 
 	sync.Mutex
 }
 
-func newStore(size int, ttl config.Duration, workers int, minUpdateInterval config.Duration) *store {
-	return &store{
-		cache:             expirable.NewLRU[string, *tagMap](size, nil, time.Duration(ttl)),
+// registry tracks every live store by its processor ID (e.g.
+// "snmp_lookup-1") so the
+// "internal_snmp_lookup" input can report metrics for it without needing a
+// direct reference to the processor instance.
+var registry sync.Map // map[string]*store
+
+func newStore(
+	id string,
+	size int,
+	ttl config.Duration,
+	workers int,
+	minUpdateInterval config.Duration,
+	health telegraf.Health,
+	persistPath string,
+	persistInterval config.Duration,
+) *store {
+	s := &store{
+		id:                id,
 		pool:              pond.New(workers, 0, pond.MinWorkers(workers/2+1)),
 		deferredUpdates:   make(map[string]time.Time),
 		minUpdateInterval: time.Duration(minUpdateInterval),
 		stopped:           false,
+		ttl:               time.Duration(ttl),
+		persistPath:       persistPath,
+	}
+	s.cache = expirable.NewLRU[string, *tagMap](size, func(string, *tagMap) {
+		s.metrics.evictions.Add(1)
+	}, time.Duration(ttl))
+
+	if health != nil {
+		s.unreachable = health.Warnable(warnableAgentUnreachable)
+		s.saturated = health.Warnable(warnablePoolSaturated)
+	}
+
+	if persistPath != "" {
+		if restored, err := loadPersisted(persistPath, s.ttl); err == nil {
+			for agent, entry := range restored {
+				s.cache.Add(agent, entry)
+			}
+		}
+
+		if persistInterval > 0 {
+			s.persistTicker = time.NewTicker(time.Duration(persistInterval))
+			s.persistDone = make(chan struct{})
+			go s.persistPeriodically()
+		}
+	}
+
+	if id != "" {
+		registry.Store(id, s)
+	}
+
+	return s
+}
+
+// persistPeriodically writes the cache to disk on every tick until
+// persistDone is closed by destroy.
+func (s *store) persistPeriodically() {
+	for {
+		select {
+		case <-s.persistTicker.C:
+			_ = s.persist(s.persistPath)
+		case <-s.persistDone:
+			return
+		}
 	}
 }
 
@@ -83,22 +158,60 @@ func (s *store) enqueue(agent string) {
 	if _, inflight := s.inflight.LoadOrStore(agent, true); inflight || s.pool.Stopped() {
 		return
 	}
+	s.metrics.inflight.Add(1)
+	s.checkPoolSaturation()
 	s.pool.Submit(func() {
+		start := time.Now()
 		entry := s.update(agent)
+		s.metrics.updates.Add(1)
+		s.metrics.updateLatencyNano.Add(int64(time.Since(start)))
+
+		if entry == nil || len(entry.rows) == 0 {
+			s.metrics.updateFailures.Add(1)
+			if s.unreachable != nil {
+				s.unreachable.Set(telegraf.HealthSeverityWarning, "agent "+agent+" returned no data")
+			}
+		} else if s.unreachable != nil {
+			s.unreachable.Clear()
+		}
+
 		s.cache.Add(agent, entry)
 		s.removeBacklog(agent)
 		s.notify(agent, entry)
 		s.inflight.Delete(agent)
+		s.metrics.inflight.Add(-1)
+		s.checkPoolSaturation()
 	})
 }
 
-func (s *store) lookup(agent, index string) {
+// getFresh looks up agent the same way s.cache.Get does, but additionally
+// treats the entry as a miss (removing it) if entry.created is already
+// older than s.ttl. This matters for entries restored from disk by
+// newStore: expirable.LRU.Add always starts its internal expiry countdown
+// from "now", so a restored entry that had already used up most of its
+// cache_ttl before the agent restarted would otherwise get a second, full
+// cache_ttl window instead of just what remained of its original one.
+func (s *store) getFresh(agent string) (*tagMap, bool) {
 	entry, cached := s.cache.Get(agent)
 	if !cached {
+		return nil, false
+	}
+	if s.ttl > 0 && time.Since(entry.created) > s.ttl {
+		s.cache.Remove(agent)
+		return nil, false
+	}
+	return entry, true
+}
+
+func (s *store) lookup(agent, index string) {
+	entry, cached := s.getFresh(agent)
+	if !cached {
+		s.metrics.misses.Add(1)
 		// There is no cache at all, so we need to enqueue an update.
 		s.enqueue(agent)
 		return
 	}
+	s.metrics.hits.Add(1)
 
 	// In case the index does not exist, we need to update the agent as this
 	// new index might have been added in the meantime (e.g. after hot-plugging
@@ -123,6 +236,10 @@ func (s *store) lookup(agent, index string) {
 }
 
 func (s *store) destroy() {
+	if s.id != "" {
+		registry.Delete(s.id)
+	}
+
 	// First, acquire lock and stop accepting new work
 	s.Lock()
 	s.stopped = true
@@ -135,9 +252,16 @@ func (s *store) destroy() {
 	}
 	s.Unlock()
 
+	if s.persistTicker != nil {
+		s.persistTicker.Stop()
+		close(s.persistDone)
+	}
+
 	// Now wait for worker pool to finish WITHOUT holding the lock
 	// This prevents the deadlock where workers need the lock in removeBacklog()
 	s.pool.StopAndWait()
+
+	_ = s.persist(s.persistPath)
 }
 
 func (s *store) purge() {
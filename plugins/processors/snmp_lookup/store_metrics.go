@@ -0,0 +1,94 @@
+package snmp_lookup
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/influxdata/telegraf"
+)
+
+// warnablePoolSaturated tracks whether the worker pool has been fully busy
+// for long enough that new lookups are starting to queue up behind it.
+const warnablePoolSaturated = "snmp_lookup.pool_saturated"
+
+// storeMetrics holds the built-in counters exposed via the
+// "internal_snmp_lookup" input and, where relevant, as telegraf.Health
+// warnables. All fields are updated with atomic operations so they can be
+// read from the internal input's Gather without taking the store lock.
+type storeMetrics struct {
+	hits              atomic.Uint64
+	misses            atomic.Uint64
+	evictions         atomic.Uint64
+	updates           atomic.Uint64
+	updateFailures    atomic.Uint64
+	updateLatencyNano atomic.Int64
+	inflight          atomic.Int64
+}
+
+// StoreStats is a point-in-time snapshot of storeMetrics together with the
+// state that can't be tracked as a simple counter (cache size, backlog
+// depth, pool utilization).
+type StoreStats struct {
+	CacheSize            int
+	Hits                 uint64
+	Misses               uint64
+	Evictions            uint64
+	Inflight             int64
+	DeferredBacklogDepth int
+	PoolRunningWorkers   int
+	PoolMaxWorkers       int
+	AverageUpdateLatency time.Duration
+}
+
+// Stats returns a snapshot of every live store's metrics, keyed by the
+// processor ID (e.g. "snmp_lookup-1") passed to newStore. It backs the
+// "internal_snmp_lookup" input, which has no other way to reach a
+// processor's internal state.
+func Stats() map[string]StoreStats {
+	out := make(map[string]StoreStats)
+	registry.Range(func(key, value any) bool {
+		out[key.(string)] = value.(*store).Stats()
+		return true
+	})
+	return out
+}
+
+// Stats returns a snapshot of the store's built-in metrics.
+func (s *store) Stats() StoreStats {
+	s.Lock()
+	backlog := len(s.deferredUpdates)
+	s.Unlock()
+
+	updates := s.metrics.updates.Load()
+	var avgLatency time.Duration
+	if updates > 0 {
+		avgLatency = time.Duration(s.metrics.updateLatencyNano.Load() / int64(updates))
+	}
+
+	return StoreStats{
+		CacheSize:            s.cache.Len(),
+		Hits:                 s.metrics.hits.Load(),
+		Misses:               s.metrics.misses.Load(),
+		Evictions:            s.metrics.evictions.Load(),
+		Inflight:             s.metrics.inflight.Load(),
+		DeferredBacklogDepth: backlog,
+		PoolRunningWorkers:   s.pool.RunningWorkers(),
+		PoolMaxWorkers:       s.pool.MaxWorkers(),
+		AverageUpdateLatency: avgLatency,
+	}
+}
+
+// checkPoolSaturation marks warnablePoolSaturated unhealthy once every
+// worker in the pool is busy, so a wedged SNMP walk shows up before the
+// deferred-update backlog grows unbounded.
+func (s *store) checkPoolSaturation() {
+	if s.saturated == nil {
+		return
+	}
+	busy := s.pool.RunningWorkers() - s.pool.IdleWorkers()
+	if busy >= s.pool.MaxWorkers() {
+		s.saturated.Set(telegraf.HealthSeverityWarning, "worker pool is fully busy, lookups are queuing up")
+		return
+	}
+	s.saturated.Clear()
+}
@@ -0,0 +1,102 @@
+package snmp_lookup
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"os"
+	"time"
+)
+
+// tagMapSnapshot is the gob-serializable mirror of tagMap's unexported
+// fields, used only for persisting the cache to disk.
+type tagMapSnapshot struct {
+	Created time.Time
+	Rows    map[string]map[string]string
+}
+
+// GobEncode implements gob.GobEncoder so tagMap, whose fields are
+// unexported, can still be persisted by store.persist.
+func (t *tagMap) GobEncode() ([]byte, error) {
+	var buf bytes.Buffer
+	snap := tagMapSnapshot{Created: t.created, Rows: t.rows}
+	if err := gob.NewEncoder(&buf).Encode(snap); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// GobDecode implements gob.GobDecoder, the counterpart to GobEncode.
+func (t *tagMap) GobDecode(data []byte) error {
+	var snap tagMapSnapshot
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&snap); err != nil {
+		return err
+	}
+	t.created = snap.Created
+	t.rows = snap.Rows
+	return nil
+}
+
+// persist writes the current cache contents to path as a gob snapshot, so a
+// restarted agent doesn't have to re-walk every device before it can resolve
+// tags again. It is a no-op if path is empty.
+func (s *store) persist(path string) error {
+	if path == "" {
+		return nil
+	}
+
+	snapshot := make(map[string]*tagMap)
+	for _, agent := range s.cache.Keys() {
+		if entry, ok := s.cache.Peek(agent); ok {
+			snapshot[agent] = entry
+		}
+	}
+
+	// Write to a temporary file first and rename into place so a crash or
+	// concurrent restart never observes a half-written cache file.
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return fmt.Errorf("creating cache persist file failed: %w", err)
+	}
+	if err := gob.NewEncoder(f).Encode(snapshot); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return fmt.Errorf("encoding cache snapshot failed: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// loadPersisted reads a cache snapshot previously written by persist,
+// dropping any entry older than ttl. A missing file is not an error.
+func loadPersisted(path string, ttl time.Duration) (map[string]*tagMap, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("opening cache persist file failed: %w", err)
+	}
+	defer f.Close()
+
+	var snapshot map[string]*tagMap
+	if err := gob.NewDecoder(f).Decode(&snapshot); err != nil {
+		return nil, fmt.Errorf("decoding cache snapshot failed: %w", err)
+	}
+
+	fresh := make(map[string]*tagMap, len(snapshot))
+	for agent, entry := range snapshot {
+		if entry == nil {
+			continue
+		}
+		if ttl > 0 && time.Since(entry.created) > ttl {
+			continue
+		}
+		fresh[agent] = entry
+	}
+	return fresh, nil
+}
@@ -0,0 +1,66 @@
+package snmp_lookup
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf/config"
+)
+
+func TestStorePersistRestoresFreshEntries(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.gob")
+
+	s := newStore("", 10, config.Duration(time.Hour), 1, config.Duration(0), nil, path, config.Duration(0))
+	s.update = func(agent string) *tagMap {
+		return &tagMap{created: time.Now(), rows: map[string]map[string]string{"0": {"agent": agent}}}
+	}
+	s.notify = func(string, *tagMap) {}
+
+	s.cache.Add("agent1", &tagMap{created: time.Now(), rows: map[string]map[string]string{"0": {"a": "1"}}})
+	s.destroy()
+
+	restarted := newStore("", 10, config.Duration(time.Hour), 1, config.Duration(0), nil, path, config.Duration(0))
+	defer restarted.destroy()
+
+	entry, cached := restarted.cache.Get("agent1")
+	require.True(t, cached)
+	require.Equal(t, map[string]string{"a": "1"}, entry.rows["0"])
+}
+
+func TestStorePersistDropsExpiredEntries(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.gob")
+
+	s := newStore("", 10, config.Duration(time.Hour), 1, config.Duration(0), nil, path, config.Duration(0))
+	s.cache.Add("stale", &tagMap{created: time.Now().Add(-2 * time.Hour), rows: map[string]map[string]string{}})
+	s.destroy()
+
+	restarted := newStore("", 10, config.Duration(time.Hour), 1, config.Duration(0), nil, path, config.Duration(0))
+	defer restarted.destroy()
+
+	_, cached := restarted.cache.Get("stale")
+	require.False(t, cached)
+}
+
+// TestGetFreshDoesNotExtendTTLPastEntryCreation guards against the cache_ttl
+// restored entries would otherwise get doubled by: newStore's
+// s.cache.Add(agent, entry) always starts the underlying expirable.LRU's
+// own expiry countdown from "now", regardless of how old entry.created
+// already is. getFresh must honor entry.created instead of trusting the
+// LRU to have expired it by the right time.
+func TestGetFreshDoesNotExtendTTLPastEntryCreation(t *testing.T) {
+	s := newStore("", 10, config.Duration(time.Hour), 1, config.Duration(0), nil, "", config.Duration(0))
+	defer s.destroy()
+
+	// As if restored from disk: added to the LRU just now, but created
+	// well past cache_ttl ago.
+	s.cache.Add("agent1", &tagMap{created: time.Now().Add(-2 * time.Hour), rows: map[string]map[string]string{"0": {"a": "1"}}})
+
+	_, cached := s.getFresh("agent1")
+	require.False(t, cached)
+
+	_, stillInLRU := s.cache.Peek("agent1")
+	require.False(t, stillInLRU)
+}